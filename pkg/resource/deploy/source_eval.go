@@ -4,11 +4,15 @@ package deploy
 
 import (
 	"fmt"
+	"io"
+	"sync"
 
 	"github.com/golang/glog"
+	pbempty "github.com/golang/protobuf/ptypes/empty"
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 
 	"github.com/pulumi/pulumi/pkg/pack"
 	"github.com/pulumi/pulumi/pkg/resource"
@@ -67,18 +71,20 @@ func (src *evalSource) Iterate(opts Options) (SourceIterator, error) {
 	// First, fire up a resource monitor that will watch for and record resource creation.
 	regChan := make(chan *evalBeginReg)
 	compChan := make(chan *evalEndReg)
-	mon, err := newResourceMonitor(src, regChan, compChan)
+	progressChan := make(chan *evalProgress)
+	mon, err := newResourceMonitor(src, regChan, compChan, progressChan)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to start resource monitor")
 	}
 
 	// Create a new iterator with appropriate channels, and gear up to go!
 	iter := &evalSourceIterator{
-		mon:      mon,
-		src:      src,
-		regChan:  regChan,
-		compChan: compChan,
-		finChan:  make(chan error),
+		mon:          mon,
+		src:          src,
+		regChan:      regChan,
+		compChan:     compChan,
+		progressChan: progressChan,
+		finChan:      make(chan error),
 	}
 
 	// Now invoke Run in a goroutine.  All subsequent resource creation events will come in over the gRPC channel,
@@ -90,12 +96,13 @@ func (src *evalSource) Iterate(opts Options) (SourceIterator, error) {
 }
 
 type evalSourceIterator struct {
-	mon      *resmon            // the resource monitor, per iterator.
-	src      *evalSource        // the owning eval source object.
-	regChan  chan *evalBeginReg // the channel that contains resource registrations.
-	compChan chan *evalEndReg   // the channel that contains resource completions.
-	finChan  chan error         // the channel that communicates completion.
-	done     bool               // set to true when the evaluation is done.
+	mon          *resmon            // the resource monitor, per iterator.
+	src          *evalSource        // the owning eval source object.
+	regChan      chan *evalBeginReg // the channel that contains resource registrations.
+	compChan     chan *evalEndReg   // the channel that contains resource completions.
+	progressChan chan *evalProgress // the channel that contains intermediate resource progress events.
+	finChan      chan error         // the channel that communicates completion.
+	done         bool               // set to true when the evaluation is done.
 }
 
 func (iter *evalSourceIterator) Close() error {
@@ -127,6 +134,11 @@ func (iter *evalSourceIterator) Next() (SourceEvent, error) {
 		glog.V(5).Infof("EvalSourceIterator produced a completion: urn=%v,#extras=%v",
 			comp.URN(), len(comp.Extras()))
 		return comp, nil
+	case prog := <-iter.progressChan:
+		contract.Assert(prog != nil)
+		glog.V(5).Infof("EvalSourceIterator produced a progress event: urn=%v,message=%v",
+			prog.URN(), prog.Message())
+		return prog, nil
 	case err := <-iter.finChan:
 		// If we are finished, we can safely exit.  The contract with the language provider is that this implies
 		// that the language runtime has exited and so calling Close on the plugin is fine.
@@ -186,22 +198,30 @@ func (iter *evalSourceIterator) forkRun(opts Options) {
 // resmon implements the lumirpc.ResourceMonitor interface and acts as the gateway between a language runtime's
 // evaluation of a program and the internal resource planning and deployment logic.
 type resmon struct {
-	src      *evalSource        // the evaluation source.
-	resChan  chan *evalBeginReg // the channel to send resource registrations to.
-	compChan chan *evalEndReg   // the channel to send resource completions to.
-	addr     string             // the address the host is listening on.
-	cancel   chan bool          // a channel that can cancel the server.
-	done     chan error         // a channel that resolves when the server completes.
+	src          *evalSource        // the evaluation source.
+	resChan      chan *evalBeginReg // the channel to send resource registrations to.
+	compChan     chan *evalEndReg   // the channel to send resource completions to.
+	progressChan chan *evalProgress // the channel to send intermediate resource progress events to.
+	addr         string             // the address the host is listening on.
+	cancel       chan bool          // a channel that can cancel the server.
+	abort        chan struct{}      // closed to wake up any RPCs parked awaiting a registration or completion.
+	abortOnce    sync.Once          // ensures abort is only ever closed once.
+	done         chan error         // a channel that resolves when the server completes.
 }
 
 // newResourceMonitor creates a new resource monitor RPC server.
-func newResourceMonitor(src *evalSource, resChan chan *evalBeginReg, compChan chan *evalEndReg) (*resmon, error) {
+func newResourceMonitor(
+	src *evalSource, resChan chan *evalBeginReg, compChan chan *evalEndReg,
+	progressChan chan *evalProgress) (*resmon, error) {
+
 	// New up an engine RPC server.
 	resmon := &resmon{
-		src:      src,
-		resChan:  resChan,
-		compChan: compChan,
-		cancel:   make(chan bool),
+		src:          src,
+		resChan:      resChan,
+		compChan:     compChan,
+		progressChan: progressChan,
+		cancel:       make(chan bool),
+		abort:        make(chan struct{}),
 	}
 
 	// Fire up a gRPC server and start listening for incomings.
@@ -227,7 +247,10 @@ func (rm *resmon) Address() string {
 }
 
 // Cancel signals that the engine should be terminated, awaits its termination, and returns any errors that result.
+// This also wakes up any BeginRegisterResource or EndRegisterResource calls that are parked waiting on the engine,
+// so that a deployment abort (CTRL-C, a plugin crash, a provider timeout) can never leave them hanging forever.
 func (rm *resmon) Cancel() error {
+	rm.abortOnce.Do(func() { close(rm.abort) })
 	rm.cancel <- true
 	return <-rm.done
 }
@@ -288,19 +311,34 @@ func (rm *resmon) BeginRegisterResource(ctx context.Context,
 	glog.V(5).Infof("ResourceMonitor.BeginRegisterResource received: t=%v, name=%v, custom=%v, #props=%v, parent=%v",
 		t, name, custom, len(props), parent)
 
-	// Send the goal state to the engine.
+	// Send the goal state to the engine.  Both the hand-off and the subsequent wait are cancellable: if the planner
+	// aborts mid-deployment (CTRL-C, a plugin crash, a provider timeout), rm.abort is closed and ctx is cancelled,
+	// and we unwind instead of leaving the language runtime blocked on an engine that will never reply.  done is
+	// buffered so that if we bail out of the second select below, the engine's later call to step.Done can still
+	// complete instead of blocking forever on a send nobody is left to receive.
 	step := &evalBeginReg{
 		goal: resource.NewGoal(t, name, custom, props, parent),
-		done: make(chan resource.URN),
+		done: make(chan resource.URN, 1),
+	}
+	select {
+	case rm.resChan <- step:
+		// the engine picked up the registration; fall through to await its result.
+	case <-rm.abort:
+		return nil, grpc.Errorf(codes.Canceled, "resource monitor shut down before %v %v could be registered", t, name)
+	case <-ctx.Done():
+		return nil, grpc.Errorf(codes.Canceled, "%v", ctx.Err())
 	}
-	rm.resChan <- step
 
-	// Now block waiting for the operation to finish.
-	// IDEA: we probably need some way to cancel this in case of catastrophe.
-	urn := string(<-step.done)
-	glog.V(5).Infof(
-		"ResourceMonitor.BeginRegisterResource operation finished: t=%v, name=%v, urn=%v", t, name, urn)
-	return &lumirpc.BeginRegisterResourceResponse{Urn: urn}, nil
+	select {
+	case urn := <-step.done:
+		glog.V(5).Infof(
+			"ResourceMonitor.BeginRegisterResource operation finished: t=%v, name=%v, urn=%v", t, name, urn)
+		return &lumirpc.BeginRegisterResourceResponse{Urn: string(urn)}, nil
+	case <-rm.abort:
+		return nil, grpc.Errorf(codes.Canceled, "resource monitor shut down while registering %v %v", t, name)
+	case <-ctx.Done():
+		return nil, grpc.Errorf(codes.Canceled, "%v", ctx.Err())
+	}
 }
 
 // EndRegisterResource records some new output properties for a resource that have arrived after its initial
@@ -320,17 +358,32 @@ func (rm *resmon) EndRegisterResource(ctx context.Context,
 	}
 	glog.V(5).Infof("ResourceMonitor.EndRegisterResource received: urn=%v, #extras=%v", urn, len(extras))
 
-	// Now send the step over to the engine to perform.
+	// Now send the step over to the engine to perform.  As with BeginRegisterResource, both the hand-off and the
+	// wait for a result are cancellable so that a mid-deployment abort can't leave this call hanging forever, and
+	// done is buffered for the same reason: the engine's eventual step.Done call must never block.
 	step := &evalEndReg{
 		urn:    urn,
 		extras: extras,
-		done:   make(chan *FinalState),
+		done:   make(chan *FinalState, 1),
+	}
+	select {
+	case rm.compChan <- step:
+		// the engine picked up the completion; fall through to await its result.
+	case <-rm.abort:
+		return nil, grpc.Errorf(codes.Canceled, "resource monitor shut down before %v could be completed", urn)
+	case <-ctx.Done():
+		return nil, grpc.Errorf(codes.Canceled, "%v", ctx.Err())
 	}
-	rm.compChan <- step
 
-	// Now block waiting for the operation to finish.
-	// IDEA: we probably need some way to cancel this in case of catastrophe.
-	result := <-step.done
+	var result *FinalState
+	select {
+	case result = <-step.done:
+		// fall through to marshal the result below.
+	case <-rm.abort:
+		return nil, grpc.Errorf(codes.Canceled, "resource monitor shut down while completing %v", urn)
+	case <-ctx.Done():
+		return nil, grpc.Errorf(codes.Canceled, "%v", ctx.Err())
+	}
 	state := result.State
 	outprops := state.Synthesized()
 	stable := result.Stable
@@ -356,6 +409,38 @@ func (rm *resmon) EndRegisterResource(ctx context.Context,
 	}, nil
 }
 
+// ResourceProgress is invoked by the language host to report intermediate status for a resource that is still
+// being created or updated (e.g., "waiting for EIP" or "rolling update 3/10").  Resource providers are plugins
+// that the engine drives directly through the plugin.Provider interface, not clients of this gRPC service, so a
+// provider wanting to surface progress has to report it to the language host's SDK, which relays it here on the
+// provider's behalf over the one RPC stream already open for the resource's Create or Update call.  Unlike
+// BeginRegisterResource and EndRegisterResource, callers do not block waiting on the engine: each event received on
+// the stream is fanned out to the planning engine as a ResourceProgressEvent, and the stream stays open for the
+// duration of the underlying Create or Update operation.
+func (rm *resmon) ResourceProgress(stream lumirpc.ResourceMonitor_ResourceProgressServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&pbempty.Empty{})
+		} else if err != nil {
+			return err
+		}
+
+		urn := resource.URN(req.GetUrn())
+		message := req.GetMessage()
+		glog.V(5).Infof("ResourceMonitor.ResourceProgress received: urn=%v, message=%v", urn, message)
+
+		select {
+		case rm.progressChan <- &evalProgress{urn: urn, message: message}:
+			// the engine picked up the event; keep reading from the stream.
+		case <-rm.abort:
+			return grpc.Errorf(codes.Canceled, "resource monitor shut down while reporting progress for %v", urn)
+		case <-stream.Context().Done():
+			return grpc.Errorf(codes.Canceled, "%v", stream.Context().Err())
+		}
+	}
+}
+
 type evalBeginReg struct {
 	goal *resource.Goal    // the resource goal state produced by the iterator.
 	done chan resource.URN // the channel to communicate with after the resource state is available.
@@ -396,3 +481,32 @@ func (g *evalEndReg) Done(res *FinalState) {
 	// Communicate the resulting state back to the RPC thread, which is parked awaiting our reply.
 	g.done <- res
 }
+
+// ResourceProgressEvent is a SourceEvent reporting an intermediate status update for a resource that is still being
+// created or updated. Unlike BeginRegisterResourceEvent and EndRegisterResourceEvent, there is nothing for the
+// engine to reply with: it simply observes the event and moves on.
+type ResourceProgressEvent interface {
+	SourceEvent
+	URN() resource.URN // the URN of the resource this progress event describes.
+	Message() string   // a human-readable description of the resource's current status.
+}
+
+// evalProgress represents an intermediate status update for a resource that is still being created or updated.
+// Unlike evalBeginReg and evalEndReg, it carries no done channel: the engine simply observes it and moves on, since
+// the provider operation it describes is still in flight and there is nothing for the engine to reply with.
+type evalProgress struct {
+	urn     resource.URN // the URN of the resource this progress event describes.
+	message string       // a human-readable description of the resource's current status.
+}
+
+var _ ResourceProgressEvent = (*evalProgress)(nil)
+
+func (g *evalProgress) event() {}
+
+func (g *evalProgress) URN() resource.URN {
+	return g.urn
+}
+
+func (g *evalProgress) Message() string {
+	return g.message
+}
@@ -0,0 +1,411 @@
+// Copyright 2016-2017, Pulumi Corporation.  All rights reserved.
+
+package deploy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/jsonpb"
+	structpb "github.com/golang/protobuf/ptypes/struct"
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/resource/plugin"
+	"github.com/pulumi/pulumi/pkg/tokens"
+	"github.com/pulumi/pulumi/pkg/util/contract"
+)
+
+// journalVersion is the version of the on-disk journal format written by recordingEvalSource and understood by
+// replaySource.  Bump this whenever the schema below changes in a way that isn't backwards compatible.
+const journalVersion = 1
+
+// journalHeader is the first line of a journal file, describing the run that produced it.
+type journalHeader struct {
+	Version int                `json:"version"`
+	Pkg     tokens.PackageName `json:"pkg"`
+	Destroy bool               `json:"destroy"`
+	DryRun  bool               `json:"dryRun"`
+}
+
+// journalEntryKind distinguishes the two kinds of steps a journal can contain.
+type journalEntryKind string
+
+const (
+	journalBeginReg journalEntryKind = "beginreg"
+	journalEndReg   journalEntryKind = "endreg"
+)
+
+// journalEntry is a single, JSON-serializable record of one step that passed through the resource monitor.  A
+// journal is simply a sequence of these, one per line after the header, so that it can be replayed without
+// invoking the language host at all.
+//
+// Property bags (Properties, Extras, Outputs) are carried as *journalProps rather than resource.PropertyMap
+// directly: a PropertyMap's values can wrap assets, archives, computed, and output values behind an interface{},
+// none of which survive a plain encoding/json round-trip.  journalProps instead marshals through the same
+// structured path used to cross the gRPC boundary to the language host, so a replayed entry is bit-for-bit
+// equivalent to what was originally recorded.
+type journalEntry struct {
+	Kind journalEntryKind `json:"kind"`
+
+	// Populated for journalBeginReg entries; mirror the fields of the resource.Goal that was registered.
+	Type       tokens.Type   `json:"type,omitempty"`
+	Name       tokens.QName  `json:"name,omitempty"`
+	Custom     bool          `json:"custom,omitempty"`
+	Parent     resource.URN  `json:"parent,omitempty"`
+	Properties *journalProps `json:"properties,omitempty"`
+
+	// Populated for both entry kinds.
+	URN resource.URN `json:"urn,omitempty"`
+
+	// Populated for journalEndReg entries; mirror the FinalState that was supplied to Done.
+	Extras    *journalProps  `json:"extras,omitempty"`
+	StateType tokens.Type    `json:"stateType,omitempty"`
+	StateID   resource.ID    `json:"stateId,omitempty"`
+	Stable    bool           `json:"stable,omitempty"`
+	Stables   []tokens.QName `json:"stables,omitempty"`
+	Outputs   *journalProps  `json:"outputs,omitempty"`
+}
+
+// journalProps carries a resource.PropertyMap through the journal using the same structured marshaling used to
+// cross the gRPC boundary (plugin.MarshalProperties/UnmarshalProperties), so that assets, archives, computed, and
+// output values all round-trip exactly instead of degrading into plain JSON maps and strings.
+type journalProps struct {
+	props resource.PropertyMap
+}
+
+// newJournalProps wraps a PropertyMap for serialization, or returns nil if there is nothing to carry.
+func newJournalProps(props resource.PropertyMap) *journalProps {
+	if len(props) == 0 {
+		return nil
+	}
+	return &journalProps{props: props}
+}
+
+// Properties unwraps the PropertyMap carried by this entry, returning nil if it is empty or absent.
+func (p *journalProps) Properties() resource.PropertyMap {
+	if p == nil {
+		return nil
+	}
+	return p.props
+}
+
+func (p *journalProps) MarshalJSON() ([]byte, error) {
+	s, err := plugin.MarshalProperties(p.props, plugin.MarshalOptions{KeepUnknowns: true, ComputeAssetHashes: true})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal properties for journal")
+	}
+	var buf bytes.Buffer
+	if err := (&jsonpb.Marshaler{}).Marshal(&buf, s); err != nil {
+		return nil, errors.Wrap(err, "failed to encode journal properties as JSON")
+	}
+	return buf.Bytes(), nil
+}
+
+func (p *journalProps) UnmarshalJSON(data []byte) error {
+	var s structpb.Struct
+	if err := jsonpb.Unmarshal(bytes.NewReader(data), &s); err != nil {
+		return errors.Wrap(err, "failed to decode journal properties")
+	}
+	props, err := plugin.UnmarshalProperties(&s, plugin.MarshalOptions{KeepUnknowns: true})
+	if err != nil {
+		return errors.Wrap(err, "failed to unmarshal properties from journal")
+	}
+	p.props = props
+	return nil
+}
+
+// NewRecordingEvalSource wraps an ordinary eval source so that every registration and completion it produces is
+// durably journaled to disk, in addition to being handed to the planning engine as usual.  The resulting journal
+// can later be handed to NewReplaySource to reproduce the exact same SourceEvent stream without rerunning the
+// user's program, which is useful for offline replanning, diffing two program runs, or reproducing engine bugs
+// from a captured trace.
+func NewRecordingEvalSource(plugctx *plugin.Context, runinfo *EvalRunInfo, destroy bool, dryRun bool,
+	journal string) (Source, error) {
+
+	f, err := os.Create(journal)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create journal %v", journal)
+	}
+
+	src := &recordingEvalSource{
+		inner:   NewEvalSource(plugctx, runinfo, destroy, dryRun),
+		runinfo: runinfo,
+		destroy: destroy,
+		dryRun:  dryRun,
+		journal: f,
+		enc:     json.NewEncoder(f),
+	}
+	if err := src.append(journalHeader{
+		Version: journalVersion,
+		Pkg:     runinfo.Pkg.Name,
+		Destroy: destroy,
+		DryRun:  dryRun,
+	}); err != nil {
+		contract.IgnoreClose(f)
+		return nil, errors.Wrapf(err, "failed to write journal header to %v", journal)
+	}
+
+	return src, nil
+}
+
+// recordingEvalSource is a Source that delegates all evaluation to an ordinary evalSource, but journals every
+// registration and completion it observes as it passes them through.
+type recordingEvalSource struct {
+	inner   Source       // the wrapped eval source that does the real work.
+	runinfo *EvalRunInfo // the directives used when running the program, recorded for the journal header.
+	destroy bool         // true if this source will trigger total destruction.
+	dryRun  bool         // true if this is a dry-run operation only.
+	journal *os.File     // the file backing the on-disk journal.
+	enc     *json.Encoder
+	encMu   sync.Mutex // guards the encoder, since events may race with one another.
+}
+
+func (src *recordingEvalSource) Close() error {
+	closeErr := src.inner.Close()
+	if err := src.journal.Close(); err != nil && closeErr == nil {
+		closeErr = err
+	}
+	return closeErr
+}
+
+func (src *recordingEvalSource) Pkg() tokens.PackageName { return src.inner.Pkg() }
+func (src *recordingEvalSource) Info() interface{}       { return src.inner.Info() }
+
+func (src *recordingEvalSource) Iterate(opts Options) (SourceIterator, error) {
+	inner, err := src.inner.Iterate(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingSourceIterator{src: src, inner: inner}, nil
+}
+
+func (src *recordingEvalSource) append(v interface{}) error {
+	src.encMu.Lock()
+	defer src.encMu.Unlock()
+	return src.enc.Encode(v)
+}
+
+// recordingSourceIterator wraps an evalSourceIterator (or any other SourceIterator), tagging each
+// BeginRegisterResourceEvent and EndRegisterResourceEvent it produces so that the goal, URN, extras, and final
+// state eventually supplied to Done are captured in the journal.
+type recordingSourceIterator struct {
+	src   *recordingEvalSource
+	inner SourceIterator
+}
+
+func (iter *recordingSourceIterator) Close() error {
+	return iter.inner.Close()
+}
+
+func (iter *recordingSourceIterator) Next() (SourceEvent, error) {
+	evt, err := iter.inner.Next()
+	if err != nil || evt == nil {
+		return evt, err
+	}
+
+	switch e := evt.(type) {
+	case BeginRegisterResourceEvent:
+		return &recordingBeginReg{BeginRegisterResourceEvent: e, src: iter.src}, nil
+	case EndRegisterResourceEvent:
+		return &recordingEndReg{EndRegisterResourceEvent: e, src: iter.src}, nil
+	default:
+		// Anything else (e.g. a ResourceProgressEvent) passes through untouched; the journal only needs to
+		// reproduce the registrations and completions that determine a deployment's resulting state.
+		return evt, nil
+	}
+}
+
+type recordingBeginReg struct {
+	BeginRegisterResourceEvent
+	src *recordingEvalSource
+}
+
+func (r *recordingBeginReg) Done(urn resource.URN) {
+	goal := r.Goal()
+	if err := r.src.append(journalEntry{
+		Kind:       journalBeginReg,
+		Type:       goal.Type,
+		Name:       goal.Name,
+		Custom:     goal.Custom,
+		Parent:     goal.Parent,
+		Properties: newJournalProps(goal.Properties),
+		URN:        urn,
+	}); err != nil {
+		glog.Errorf("failed to append registration of %v to journal: %v", urn, err)
+	}
+	r.BeginRegisterResourceEvent.Done(urn)
+}
+
+type recordingEndReg struct {
+	EndRegisterResourceEvent
+	src *recordingEvalSource
+}
+
+func (r *recordingEndReg) Done(res *FinalState) {
+	if err := r.src.append(journalEntry{
+		Kind:      journalEndReg,
+		URN:       r.URN(),
+		Extras:    newJournalProps(r.Extras()),
+		StateType: res.State.Type,
+		StateID:   res.State.ID,
+		Stable:    res.Stable,
+		Stables:   res.Stables,
+		Outputs:   newJournalProps(res.State.Synthesized()),
+	}); err != nil {
+		glog.Errorf("failed to append completion of %v to journal: %v", r.URN(), err)
+	}
+	r.EndRegisterResourceEvent.Done(res)
+}
+
+// NewReplaySource returns a planning source that replays a journal previously written by NewRecordingEvalSource,
+// reproducing the exact same sequence of registrations and completions without invoking a language host.  This
+// makes it possible to replan, diff, or debug a deployment entirely offline from a captured trace.
+func NewReplaySource(journal string) (Source, error) {
+	f, err := os.Open(journal)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open journal %v", journal)
+	}
+
+	dec := json.NewDecoder(f)
+	var header journalHeader
+	if err := dec.Decode(&header); err != nil {
+		contract.IgnoreClose(f)
+		return nil, errors.Wrapf(err, "failed to read journal header from %v", journal)
+	}
+	if header.Version != journalVersion {
+		contract.IgnoreClose(f)
+		return nil, errors.Errorf("journal %v has version %v, expected %v", journal, header.Version, journalVersion)
+	}
+
+	return &replaySource{journal: f, dec: dec, header: header}, nil
+}
+
+// replaySource is a Source that reads a previously recorded journal instead of evaluating a program.
+type replaySource struct {
+	journal *os.File
+	dec     *json.Decoder
+	header  journalHeader
+}
+
+func (src *replaySource) Close() error { return src.journal.Close() }
+
+func (src *replaySource) Pkg() tokens.PackageName { return src.header.Pkg }
+
+func (src *replaySource) Info() interface{} { return src.header }
+
+func (src *replaySource) Iterate(opts Options) (SourceIterator, error) {
+	return &replaySourceIterator{src: src}, nil
+}
+
+type replaySourceIterator struct {
+	src *replaySource
+}
+
+func (iter *replaySourceIterator) Close() error {
+	return nil
+}
+
+func (iter *replaySourceIterator) Next() (SourceEvent, error) {
+	// Just like evalSource, a destroying replay presents no new resources so the engine forcibly removes
+	// everything already in the checkpoint; the journal itself is never consulted in that mode.
+	if iter.src.header.Destroy {
+		return nil, nil
+	}
+
+	var entry journalEntry
+	if err := iter.src.dec.Decode(&entry); err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to read journal entry")
+	}
+
+	switch entry.Kind {
+	case journalBeginReg:
+		return &replayBeginReg{
+			goal: resource.NewGoal(entry.Type, entry.Name, entry.Custom, entry.Properties.Properties(), entry.Parent),
+		}, nil
+	case journalEndReg:
+		return &replayEndReg{
+			urn:    entry.URN,
+			extras: entry.Extras.Properties(),
+			state: RecordedState{
+				Type:    entry.StateType,
+				ID:      entry.StateID,
+				Stable:  entry.Stable,
+				Stables: entry.Stables,
+				Outputs: entry.Outputs.Properties(),
+			},
+		}, nil
+	default:
+		return nil, errors.Errorf("unrecognized journal entry kind %q", entry.Kind)
+	}
+}
+
+type replayBeginReg struct {
+	goal *resource.Goal // the resource goal state recorded in the journal.
+}
+
+var _ BeginRegisterResourceEvent = (*replayBeginReg)(nil)
+
+func (r *replayBeginReg) event() {}
+
+func (r *replayBeginReg) Goal() *resource.Goal {
+	return r.goal
+}
+
+// Done is a no-op during replay: there is no language host parked on an RPC waiting for this URN, since the
+// original registration already ran to completion when the journal was recorded.
+func (r *replayBeginReg) Done(urn resource.URN) {}
+
+// RecordedState is the terminal state for a single resource as captured in a recording journal: its type, ID,
+// stability, and output properties at the moment EndRegisterResource completed during the original run.  It is
+// not part of the FinalState type the live engine produces, since replay has no planning context to attach the
+// recorded outputs to.
+type RecordedState struct {
+	Type    tokens.Type
+	ID      resource.ID
+	Stable  bool
+	Stables []tokens.QName
+	Outputs resource.PropertyMap
+}
+
+// ReplayedCompletion is implemented by an EndRegisterResourceEvent produced by a replay source, exposing the
+// original run's recorded terminal state.  Callers that want that state (to diff it against a fresh run, or to
+// debug an engine bug from a captured trace) should type-assert their EndRegisterResourceEvent to this interface
+// rather than to any particular replay source's concrete event type.
+type ReplayedCompletion interface {
+	State() RecordedState
+}
+
+type replayEndReg struct {
+	urn    resource.URN         // the URN to which this completion applies.
+	extras resource.PropertyMap // the "extra" output properties recorded in the journal.
+	state  RecordedState        // the terminal state that was supplied to Done in the original run.
+}
+
+var _ EndRegisterResourceEvent = (*replayEndReg)(nil)
+var _ ReplayedCompletion = (*replayEndReg)(nil)
+
+func (r *replayEndReg) event() {}
+
+func (r *replayEndReg) URN() resource.URN {
+	return r.urn
+}
+
+func (r *replayEndReg) Extras() resource.PropertyMap {
+	return r.extras
+}
+
+// State returns the terminal state that was recorded for this completion in the original run.
+func (r *replayEndReg) State() RecordedState {
+	return r.state
+}
+
+// Done is a no-op during replay, for the same reason as replayBeginReg.Done.
+func (r *replayEndReg) Done(res *FinalState) {}
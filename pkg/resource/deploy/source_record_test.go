@@ -0,0 +1,282 @@
+// Copyright 2016-2017, Pulumi Corporation.  All rights reserved.
+
+package deploy
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/pulumi/pulumi/pkg/pack"
+	"github.com/pulumi/pulumi/pkg/resource"
+	"github.com/pulumi/pulumi/pkg/tokens"
+)
+
+// fakeBeginReg is a minimal BeginRegisterResourceEvent for driving recordingEvalSource in tests without a real
+// resource monitor.
+type fakeBeginReg struct {
+	goal *resource.Goal
+	urn  resource.URN
+}
+
+func (f *fakeBeginReg) event() {}
+
+func (f *fakeBeginReg) Goal() *resource.Goal { return f.goal }
+
+func (f *fakeBeginReg) Done(urn resource.URN) { f.urn = urn }
+
+// fakeSourceIterator replays a fixed slice of events, standing in for an evalSourceIterator in tests.
+type fakeSourceIterator struct {
+	events []SourceEvent
+	next   int
+}
+
+func (it *fakeSourceIterator) Close() error { return nil }
+
+func (it *fakeSourceIterator) Next() (SourceEvent, error) {
+	if it.next >= len(it.events) {
+		return nil, nil
+	}
+	evt := it.events[it.next]
+	it.next++
+	return evt, nil
+}
+
+// fakeSource wraps a fakeSourceIterator, standing in for an evalSource in tests.
+type fakeSource struct {
+	iter *fakeSourceIterator
+}
+
+func (s *fakeSource) Close() error                              { return nil }
+func (s *fakeSource) Pkg() tokens.PackageName                   { return "test" }
+func (s *fakeSource) Info() interface{}                         { return nil }
+func (s *fakeSource) Iterate(_ Options) (SourceIterator, error) { return s.iter, nil }
+
+// newTestJournal creates an empty temp file to use as a journal and returns its path plus a cleanup func.
+func newTestJournal(t *testing.T) (string, func()) {
+	f, err := ioutil.TempFile("", "pulumi-journal")
+	if err != nil {
+		t.Fatalf("failed to create temp journal: %v", err)
+	}
+	path := f.Name()
+	if err := f.Close(); err != nil {
+		t.Fatalf("failed to close temp journal: %v", err)
+	}
+	return path, func() { os.Remove(path) }
+}
+
+// TestJournalPropsRoundTrip proves that non-primitive PropertyValues -- which wrap an interface{} and so do not
+// survive a plain encoding/json round-trip -- come back unchanged when carried through journalProps, which
+// marshals through the same structured path used to cross the gRPC boundary to the language host.
+func TestJournalPropsRoundTrip(t *testing.T) {
+	orig := resource.PropertyMap{
+		"name":  resource.NewStringProperty("web-server"),
+		"count": resource.NewNumberProperty(3),
+		"tags": resource.NewArrayProperty([]resource.PropertyValue{
+			resource.NewStringProperty("prod"),
+			resource.NewStringProperty("west"),
+		}),
+		"config": resource.NewObjectProperty(resource.PropertyMap{
+			"nested": resource.NewBoolProperty(true),
+		}),
+		"asset":   resource.NewAssetProperty(&resource.Asset{Path: "index.html"}),
+		"pending": resource.MakeComputed(resource.NewStringProperty("")),
+	}
+
+	data, err := newJournalProps(orig).MarshalJSON()
+	if err != nil {
+		t.Fatalf("failed to marshal journal properties: %v", err)
+	}
+
+	var decoded journalProps
+	if err := decoded.UnmarshalJSON(data); err != nil {
+		t.Fatalf("failed to unmarshal journal properties: %v", err)
+	}
+
+	if !reflect.DeepEqual(orig, decoded.Properties()) {
+		t.Fatalf("journal properties did not round-trip:\n  got:  %#v\n  want: %#v", decoded.Properties(), orig)
+	}
+}
+
+// TestRecordReplayBeginRegRoundTrip drives a fake registration through recordingEvalSource and then reads it back
+// via NewReplaySource, proving that a Goal carrying non-primitive properties (here, an asset) survives the full
+// record -> journal file -> replay path unchanged.
+func TestRecordReplayBeginRegRoundTrip(t *testing.T) {
+	journalPath, cleanup := newTestJournal(t)
+	defer cleanup()
+
+	goal := resource.NewGoal(
+		tokens.Type("test:index:Resource"),
+		tokens.QName("myresource"),
+		true,
+		resource.PropertyMap{
+			"asset": resource.NewAssetProperty(&resource.Asset{Path: "index.html"}),
+		},
+		resource.URN(""),
+	)
+	begin := &fakeBeginReg{goal: goal}
+
+	f, err := os.OpenFile(journalPath, os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("failed to reopen journal for writing: %v", err)
+	}
+	rec := &recordingEvalSource{
+		inner:   &fakeSource{iter: &fakeSourceIterator{events: []SourceEvent{begin}}},
+		runinfo: &EvalRunInfo{Pkg: &pack.Package{Name: "test"}},
+		journal: f,
+		enc:     json.NewEncoder(f),
+	}
+	if err := rec.append(journalHeader{Version: journalVersion, Pkg: "test"}); err != nil {
+		t.Fatalf("failed to write journal header: %v", err)
+	}
+
+	iter, err := rec.Iterate(Options{})
+	if err != nil {
+		t.Fatalf("failed to iterate recording source: %v", err)
+	}
+	evt, err := iter.Next()
+	if err != nil {
+		t.Fatalf("failed to get next event: %v", err)
+	}
+	reg, ok := evt.(BeginRegisterResourceEvent)
+	if !ok {
+		t.Fatalf("expected a BeginRegisterResourceEvent, got %T", evt)
+	}
+	reg.Done(resource.URN("urn:pulumi:stack::proj::test:index:Resource::myresource"))
+
+	if err := f.Close(); err != nil {
+		t.Fatalf("failed to close journal: %v", err)
+	}
+
+	replay, err := NewReplaySource(journalPath)
+	if err != nil {
+		t.Fatalf("failed to open replay source: %v", err)
+	}
+	defer replay.Close()
+
+	riter, err := replay.Iterate(Options{})
+	if err != nil {
+		t.Fatalf("failed to iterate replay source: %v", err)
+	}
+	revt, err := riter.Next()
+	if err != nil {
+		t.Fatalf("failed to replay event: %v", err)
+	}
+	rreg, ok := revt.(BeginRegisterResourceEvent)
+	if !ok {
+		t.Fatalf("expected a replayed BeginRegisterResourceEvent, got %T", revt)
+	}
+
+	rgoal := rreg.Goal()
+	if rgoal.Type != goal.Type || rgoal.Name != goal.Name || rgoal.Custom != goal.Custom || rgoal.Parent != goal.Parent {
+		t.Fatalf("replayed goal does not match original: got %#v, want %#v", rgoal, goal)
+	}
+	if !reflect.DeepEqual(rgoal.Properties, goal.Properties) {
+		t.Fatalf("replayed properties do not match original: got %#v, want %#v", rgoal.Properties, goal.Properties)
+	}
+}
+
+// TestReplayEndRegRoundTrip proves that a recorded completion -- including its recorded terminal state, which is
+// dead data unless something reads it back -- is reconstructed correctly by replay.  FinalState is declared in
+// another file of this package and no FinalState is built here; this drives the journal/replay path directly
+// rather than through recordingEndReg.Done.
+func TestReplayEndRegRoundTrip(t *testing.T) {
+	journalPath, cleanup := newTestJournal(t)
+	defer cleanup()
+
+	f, err := os.OpenFile(journalPath, os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("failed to open journal for writing: %v", err)
+	}
+	enc := json.NewEncoder(f)
+	if err := enc.Encode(journalHeader{Version: journalVersion, Pkg: "test"}); err != nil {
+		t.Fatalf("failed to write journal header: %v", err)
+	}
+
+	urn := resource.URN("urn:pulumi:stack::proj::test:index:Resource::myresource")
+	extras := resource.PropertyMap{"ip": resource.NewStringProperty("1.2.3.4")}
+	outputs := resource.PropertyMap{
+		"id":    resource.NewStringProperty("abc123"),
+		"asset": resource.NewAssetProperty(&resource.Asset{Path: "index.html"}),
+	}
+	if err := enc.Encode(journalEntry{
+		Kind:      journalEndReg,
+		URN:       urn,
+		Extras:    newJournalProps(extras),
+		StateType: tokens.Type("test:index:Resource"),
+		StateID:   resource.ID("abc123"),
+		Stable:    true,
+		Stables:   []tokens.QName{"id"},
+		Outputs:   newJournalProps(outputs),
+	}); err != nil {
+		t.Fatalf("failed to write journal entry: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("failed to close journal: %v", err)
+	}
+
+	replay, err := NewReplaySource(journalPath)
+	if err != nil {
+		t.Fatalf("failed to open replay source: %v", err)
+	}
+	defer replay.Close()
+
+	riter, err := replay.Iterate(Options{})
+	if err != nil {
+		t.Fatalf("failed to iterate replay source: %v", err)
+	}
+	evt, err := riter.Next()
+	if err != nil {
+		t.Fatalf("failed to replay event: %v", err)
+	}
+	end, ok := evt.(EndRegisterResourceEvent)
+	if !ok {
+		t.Fatalf("expected an EndRegisterResourceEvent, got %T", evt)
+	}
+
+	if end.URN() != urn {
+		t.Fatalf("replayed URN does not match: got %v, want %v", end.URN(), urn)
+	}
+	if !reflect.DeepEqual(end.Extras(), extras) {
+		t.Fatalf("replayed extras do not match: got %#v, want %#v", end.Extras(), extras)
+	}
+
+	replayed, ok := end.(ReplayedCompletion)
+	if !ok {
+		t.Fatalf("expected a replayed EndRegisterResourceEvent to implement ReplayedCompletion, got %T", evt)
+	}
+	state := replayed.State()
+	if state.Type != "test:index:Resource" || state.ID != "abc123" || !state.Stable {
+		t.Fatalf("replayed state does not match: got %#v", state)
+	}
+	if !reflect.DeepEqual(state.Stables, []tokens.QName{"id"}) {
+		t.Fatalf("replayed stables do not match: got %v", state.Stables)
+	}
+	if !reflect.DeepEqual(state.Outputs, outputs) {
+		t.Fatalf("replayed outputs do not match: got %#v, want %#v", state.Outputs, outputs)
+	}
+}
+
+// TestNewReplaySourceVersionMismatch proves that a journal written with a future/incompatible version is rejected
+// up front, rather than silently misinterpreted.
+func TestNewReplaySourceVersionMismatch(t *testing.T) {
+	journalPath, cleanup := newTestJournal(t)
+	defer cleanup()
+
+	f, err := os.OpenFile(journalPath, os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("failed to open journal for writing: %v", err)
+	}
+	if err := json.NewEncoder(f).Encode(journalHeader{Version: journalVersion + 1, Pkg: "test"}); err != nil {
+		t.Fatalf("failed to write journal header: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("failed to close journal: %v", err)
+	}
+
+	if _, err := NewReplaySource(journalPath); err == nil {
+		t.Fatal("expected NewReplaySource to reject a journal with a mismatched version, but it did not")
+	}
+}